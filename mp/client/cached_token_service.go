@@ -0,0 +1,230 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+var _ TokenService = new(CachedTokenService)
+
+// refreshLockTTL 是分布式刷新锁的过期时间, 需要大于一次 /cgi-bin/token 请求的正常耗时,
+// 避免锁的持有者还没来得及 Unlock, 锁就因为超时被其他进程抢走, 造成重复刷新.
+const refreshLockTTL = 30 * time.Second
+
+// followerPollInterval/followerMaxWait 控制没抢到锁的实例等待 leader 写入新
+// token 的节奏: 每隔 followerPollInterval 重新尝试一次(重新读 Storage, 顺便
+// 再抢一次锁), 超过 followerMaxWait 还没等到可用的 token 就放弃并报错,
+// 不能无限等待也不能把空 token 当成功返回.
+// 用 var 而不是 const, 方便测试缩短这两个值.
+var (
+	followerPollInterval = 500 * time.Millisecond
+	followerMaxWait      = 10 * time.Second
+)
+
+// CachedTokenService 是多进程/多机器共享同一个 appid 时使用的 TokenService 实现.
+//
+// 所有实例共用同一个 Storage 来保存当前的 access token, 同一时刻只有抢到分布式锁的
+// 那个实例(leader)才会真正请求微信服务器, 其余实例只是从 Storage 里读取 leader 写入的
+// 结果, 从而让一堆共享同一个 appid 的进程/pod 总共只消耗一份 2000次/日 的配额.
+//
+// 同一进程内部并发调用 TokenRefresh 会被 singleflight 合并成一次实际的刷新动作.
+type CachedTokenService struct {
+	appid, appsecret string
+	storage          Storage
+	httpClient       *http.Client
+
+	refreshGroup singleflight.Group
+
+	currentToken struct {
+		rwmutex sync.RWMutex
+		token   string
+		err     error
+	}
+	resetRefreshTickChan chan time.Duration
+}
+
+// NewCachedTokenService 创建一个新的 CachedTokenService.
+// storage 负责在实例之间共享 access token 以及抢占刷新锁, 可以用 NewRedisStorage
+// 或 NewSQLStorage, 也可以自己实现 Storage 接口.
+func NewCachedTokenService(appid, appsecret string, storage Storage, httpClient *http.Client) (srv *CachedTokenService) {
+	srv = &CachedTokenService{
+		appid:                appid,
+		appsecret:            appsecret,
+		storage:              storage,
+		resetRefreshTickChan: make(chan time.Duration),
+	}
+	if httpClient == nil {
+		srv.httpClient = http.DefaultClient
+	} else {
+		srv.httpClient = httpClient
+	}
+
+	token, expiresAt, err := srv.loadOrRefresh()
+	if err != nil {
+		srv.currentToken.token = ""
+		srv.currentToken.err = err
+		go srv.tokenAutoUpdate(time.Minute) // 一分钟后尝试
+	} else {
+		srv.currentToken.token = token
+		srv.currentToken.err = nil
+		go srv.tokenAutoUpdate(time.Until(expiresAt))
+	}
+	return
+}
+
+func (srv *CachedTokenService) Token() (token string, err error) {
+	srv.currentToken.rwmutex.RLock()
+	token = srv.currentToken.token
+	err = srv.currentToken.err
+	srv.currentToken.rwmutex.RUnlock()
+	return
+}
+
+// TokenRefresh 请参考 TokenService.TokenRefresh 的注意事项.
+// 在 CachedTokenService 里, 只有抢到分布式锁的实例才会真正请求微信服务器,
+// 没抢到锁的实例会稍等片刻再从 Storage 读取 leader 写入的最新 token.
+// Storage 里的 token 还没过期时, TokenRefresh 直接返回这个 token, 不会请求微信服务器.
+func (srv *CachedTokenService) TokenRefresh() (token string, err error) {
+	token, expiresAt, err := srv.loadOrRefresh()
+
+	srv.currentToken.rwmutex.Lock()
+	defer srv.currentToken.rwmutex.Unlock()
+
+	if err != nil {
+		srv.currentToken.token = ""
+		srv.currentToken.err = err
+		srv.resetRefreshTickChan <- time.Minute // 一分钟后尝试
+		return "", err
+	}
+
+	srv.currentToken.token = token
+	srv.currentToken.err = nil
+	srv.resetRefreshTickChan <- time.Until(expiresAt)
+	return token, nil
+}
+
+// loadOrRefresh 优先从 Storage 读取还没过期的 access token; 如果已经过期或者不存在,
+// 就去抢分布式锁, 抢到的实例负责请求微信服务器并把结果写回 Storage, 没抢到的实例
+// 等待片刻后重新从 Storage 读取. 同一进程内的并发调用由 singleflight 合并.
+func (srv *CachedTokenService) loadOrRefresh() (token string, expiresAt time.Time, err error) {
+	v, err, _ := srv.refreshGroup.Do(srv.appid, func() (interface{}, error) {
+		return srv.loadOrRefreshUncombined()
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	result := v.(tokenAndExpiry)
+	return result.token, result.expiresAt, nil
+}
+
+type tokenAndExpiry struct {
+	token     string
+	expiresAt time.Time
+}
+
+// loadOrRefreshUncombined 要么返回一个非空且没过期的 token, 要么返回一个明确的
+// error, 绝不能返回 ("", zero-time, nil) —— 调用方(WechatHTTPClient, getNewTicket
+// 等)只检查 err, 空 token 会被当成功拿到, 进而带着 access_token= 的空值去请求微信.
+func (srv *CachedTokenService) loadOrRefreshUncombined() (tokenAndExpiry, error) {
+	deadline := time.Now().Add(followerMaxWait)
+
+	for {
+		token, expiresAt, err := srv.storage.Get()
+		if err != nil {
+			return tokenAndExpiry{}, err
+		}
+		if token != "" && expiresAt.After(time.Now()) {
+			return tokenAndExpiry{token, expiresAt}, nil
+		}
+
+		ok, err := srv.storage.Lock(refreshLockTTL)
+		if err != nil {
+			return tokenAndExpiry{}, err
+		}
+		if ok {
+			return srv.refreshAndUnlock()
+		}
+
+		// 没抢到锁, 说明别的实例正在刷新, 稍等片刻再重新读取/抢锁,
+		// 直到等到 leader 写入的可用 token, 或者彻底超时放弃.
+		if time.Now().After(deadline) {
+			return tokenAndExpiry{}, fmt.Errorf(
+				"client: 等待其他实例刷新 access token 超时(appid=%s)", srv.appid)
+		}
+		time.Sleep(followerPollInterval)
+	}
+}
+
+// refreshAndUnlock 在已经抢到分布式锁的前提下真正请求微信服务器, 无论成功与否
+// 都要 Unlock, 让其他等待中的实例可以继续抢锁.
+func (srv *CachedTokenService) refreshAndUnlock() (tokenAndExpiry, error) {
+	defer srv.storage.Unlock()
+
+	resp, err := getNewToken(srv.httpClient, srv.appid, srv.appsecret)
+	if err != nil {
+		return tokenAndExpiry{}, err
+	}
+
+	expiresAt := time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	if err = srv.storage.Set(resp.Token, expiresAt); err != nil {
+		return tokenAndExpiry{}, err
+	}
+	return tokenAndExpiry{resp.Token, expiresAt}, nil
+}
+
+// 单独一个 goroutine 来定时获取 access token, 逻辑与 DefaultTokenService.tokenAutoUpdate
+// 一致, 只是每一轮都要先看看 Storage 里是不是已经有其他实例刷新过的 token.
+func (srv *CachedTokenService) tokenAutoUpdate(tickDuration time.Duration) {
+	const defaultTickDuration = time.Minute
+	if tickDuration <= 0 {
+		tickDuration = defaultTickDuration
+	}
+	var ticker *time.Ticker
+
+NEW_TICK_DURATION:
+	ticker = time.NewTicker(tickDuration)
+	for {
+		select {
+		case tickDuration = <-srv.resetRefreshTickChan:
+			ticker.Stop()
+			goto NEW_TICK_DURATION
+
+		case <-ticker.C:
+			srv.currentToken.rwmutex.Lock()
+
+			token, expiresAt, err := srv.loadOrRefresh()
+			if err != nil {
+				srv.currentToken.token = ""
+				srv.currentToken.err = err
+
+				srv.currentToken.rwmutex.Unlock()
+
+				if tickDuration != defaultTickDuration {
+					ticker.Stop()
+					tickDuration = defaultTickDuration
+					goto NEW_TICK_DURATION
+				}
+
+			} else {
+				srv.currentToken.token = token
+				srv.currentToken.err = nil
+
+				srv.currentToken.rwmutex.Unlock()
+
+				newTickDuration := time.Until(expiresAt)
+				if newTickDuration <= 0 {
+					newTickDuration = defaultTickDuration
+				}
+				if tickDuration != newTickDuration {
+					ticker.Stop()
+					tickDuration = newTickDuration
+					goto NEW_TICK_DURATION
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeStorage 模拟 "这个 appid 从来没有哪个实例抢到过锁" 的场景:
+// Get 永远返回空 token, Lock 永远抢不到.
+type fakeStorage struct{}
+
+func (fakeStorage) Get() (token string, expiresAt time.Time, err error) {
+	return "", time.Time{}, nil
+}
+
+func (fakeStorage) Set(token string, expiresAt time.Time) error {
+	return nil
+}
+
+func (fakeStorage) Lock(ttl time.Duration) (ok bool, err error) {
+	return false, nil
+}
+
+func (fakeStorage) Unlock() error {
+	return nil
+}
+
+// 回归测试: loadOrRefreshUncombined 曾经在抢不到锁时只 sleep 一次再读一次
+// Storage, leader 还没写入时就会返回 ("", zero-time, nil), 违反了
+// TokenService 的约定. 现在必须要么等到一个非空且未过期的 token, 要么
+// 返回一个明确的 error, 绝不能两者都是零值.
+func TestCachedTokenServiceLoadOrRefreshUncombinedNeverReturnsEmptyTokenWithoutError(t *testing.T) {
+	origPoll, origWait := followerPollInterval, followerMaxWait
+	followerPollInterval = time.Millisecond
+	followerMaxWait = 20 * time.Millisecond
+	defer func() {
+		followerPollInterval = origPoll
+		followerMaxWait = origWait
+	}()
+
+	srv := &CachedTokenService{
+		appid:   "test-appid",
+		storage: fakeStorage{},
+	}
+
+	result, err := srv.loadOrRefreshUncombined()
+	if err == nil {
+		t.Fatalf("expected a timeout error, got nil err with result %+v", result)
+	}
+	if result.token != "" {
+		t.Fatalf("expected an empty token alongside a non-nil error, got token %q", result.token)
+	}
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+var _ CardTicketService = new(DefaultCardTicketService)
+
+// DefaultCardTicketService 是 CardTicketService 的默认实现, 结构和
+// DefaultJSAPITicketService 完全一致, 只是换取的 ticket type 是 "wx_card".
+type DefaultCardTicketService struct {
+	tokenService TokenService
+
+	currentTicket struct {
+		rwmutex sync.RWMutex
+		ticket  string
+		err     error
+	}
+	resetTicketRefreshTickChan chan time.Duration
+
+	httpClient *WechatHTTPClient
+}
+
+func NewDefaultCardTicketService(tokenService TokenService, httpClient *http.Client) (srv *DefaultCardTicketService) {
+	srv = &DefaultCardTicketService{
+		tokenService:               tokenService,
+		resetTicketRefreshTickChan: make(chan time.Duration),
+		httpClient:                 NewWechatHTTPClient("wx_card_ticket", tokenService, httpClient),
+	}
+
+	resp, err := srv.getNewTicket()
+	if err != nil {
+		srv.currentTicket.ticket = ""
+		srv.currentTicket.err = err
+		go srv.ticketAutoUpdate(time.Minute)
+	} else {
+		srv.currentTicket.ticket = resp.Ticket
+		srv.currentTicket.err = nil
+		go srv.ticketAutoUpdate(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+	return
+}
+
+func (srv *DefaultCardTicketService) Ticket() (ticket string, err error) {
+	srv.currentTicket.rwmutex.RLock()
+	ticket = srv.currentTicket.ticket
+	err = srv.currentTicket.err
+	srv.currentTicket.rwmutex.RUnlock()
+	return
+}
+
+func (srv *DefaultCardTicketService) TicketRefresh() (ticket string, err error) {
+	srv.currentTicket.rwmutex.Lock()
+	defer srv.currentTicket.rwmutex.Unlock()
+
+	resp, err := srv.getNewTicket()
+	if err != nil {
+		srv.currentTicket.ticket = ""
+		srv.currentTicket.err = err
+		srv.resetTicketRefreshTickChan <- time.Minute
+		return
+	}
+
+	ticket = resp.Ticket
+
+	srv.currentTicket.ticket = resp.Ticket
+	srv.currentTicket.err = nil
+	srv.resetTicketRefreshTickChan <- time.Duration(resp.ExpiresIn) * time.Second
+	return
+}
+
+func (srv *DefaultCardTicketService) getNewTicket() (resp *ticketResponse, err error) {
+	return getNewTicket(srv.httpClient, "wx_card")
+}
+
+// 单独一个 goroutine 来定时获取 wx_card ticket, 与 (*DefaultTokenService).tokenAutoUpdate
+// 完全相同的结构.
+func (srv *DefaultCardTicketService) ticketAutoUpdate(tickDuration time.Duration) {
+	const defaultTickDuration = time.Minute
+	var ticker *time.Ticker
+
+NEW_TICK_DURATION:
+	ticker = time.NewTicker(tickDuration)
+	for {
+		select {
+		case tickDuration = <-srv.resetTicketRefreshTickChan:
+			ticker.Stop()
+			goto NEW_TICK_DURATION
+
+		case <-ticker.C:
+			srv.currentTicket.rwmutex.Lock()
+
+			resp, err := srv.getNewTicket()
+			if err != nil {
+				srv.currentTicket.ticket = ""
+				srv.currentTicket.err = err
+
+				srv.currentTicket.rwmutex.Unlock()
+
+				if tickDuration != defaultTickDuration {
+					ticker.Stop()
+					tickDuration = defaultTickDuration
+					goto NEW_TICK_DURATION
+				}
+
+			} else {
+				srv.currentTicket.ticket = resp.Ticket
+				srv.currentTicket.err = nil
+
+				srv.currentTicket.rwmutex.Unlock()
+
+				newTickDuration := time.Duration(resp.ExpiresIn) * time.Second
+				if tickDuration != newTickDuration {
+					ticker.Stop()
+					tickDuration = newTickDuration
+					goto NEW_TICK_DURATION
+				}
+			}
+		}
+	}
+}
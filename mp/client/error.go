@@ -0,0 +1,14 @@
+package client
+
+import "fmt"
+
+// Error 是微信服务器返回的错误信息, token_service.go 和本包其余文件里的
+// `Error` 内嵌字段/`&result.Error` 用的就是这个类型.
+type Error struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("errcode: %d, errmsg: %s", e.ErrCode, e.ErrMsg)
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+var _ TokenStore = new(FileTokenStore)
+
+// FileTokenStore 把 access token 保存在一个本地文件里, 适合单机单进程部署.
+type FileTokenStore struct {
+	mutex sync.Mutex
+	path  string
+}
+
+// NewFileTokenStore 创建一个新的 FileTokenStore, path 是保存 token 的文件路径,
+// 文件不存在也没有关系, 第一次 Save 的时候会自动创建.
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+type fileTokenStoreData struct {
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expires_at"` // unix 时间戳
+}
+
+func (s *FileTokenStore) Load() (token string, expiresAt time.Time, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return "", time.Time{}, nil
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	var v fileTokenStoreData
+	if err = json.Unmarshal(data, &v); err != nil {
+		return "", time.Time{}, err
+	}
+	return v.Token, time.Unix(v.ExpiresAt, 0), nil
+}
+
+func (s *FileTokenStore) Save(token string, expiresAt time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.Marshal(fileTokenStoreData{
+		Token:     token,
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, data, 0600)
+}
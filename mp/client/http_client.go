@@ -0,0 +1,167 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// 这几个错误代码代表 access_token 已经失效(过期, 被冻结, 或者干脆是错的),
+// 收到其中任何一个都应该刷新 token 之后重试一次.
+const (
+	errCodeInvalidAccessToken = 40001
+	errCodeIllegalAccessToken = 40014
+	errCodeExpiredAccessToken = 42001
+)
+
+// minRefreshInterval 是同一个 WechatHTTPClient 两次真正调用 TokenRefresh 之间的
+// 最小间隔, 避免一瞬间涌入的大量 40001 把 TokenRefresh 打爆, 造成雪崩.
+const minRefreshInterval = 5 * time.Second
+
+// WechatHTTPClient 包装了 *http.Client 和 TokenService, 当微信服务器返回
+// errcode 40001/40014/42001 时会自动刷新 access_token 并重试一次, 调用方
+// 不需要再关心 access_token 过期的问题. 所有新写的 API 绑定都应该通过这个
+// client 发请求, 而不是直接用 http.Client.
+type WechatHTTPClient struct {
+	httpClient   *http.Client
+	tokenService TokenService
+
+	refreshGroup singleflight.Group
+	appid        string
+
+	mutex       sync.Mutex
+	lastRefresh time.Time
+}
+
+// NewWechatHTTPClient 创建一个新的 WechatHTTPClient.
+// appid 只是 singleflight 的 key, 用来在一个进程里管理多个公众号时互不干扰.
+func NewWechatHTTPClient(appid string, tokenService TokenService, httpClient *http.Client) *WechatHTTPClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &WechatHTTPClient{
+		httpClient:   httpClient,
+		tokenService: tokenService,
+		appid:        appid,
+	}
+}
+
+// wechatErrorEnvelope 用来从任意一个微信 JSON 响应里探出 errcode/errmsg,
+// 不关心其余字段.
+type wechatErrorEnvelope struct {
+	Error
+}
+
+// Get 请求 rawURL(不带 access_token), 自动在查询串里补上 access_token,
+// 如果响应的 errcode 是 40001/40014/42001 会刷新 token 并重试一次.
+// 返回值是响应体的原始字节, 调用方自己按需要 json.Unmarshal.
+func (c *WechatHTTPClient) Get(rawURL string) (body []byte, err error) {
+	return c.doWithRetry(func(token string) (*http.Response, error) {
+		return c.httpClient.Get(appendAccessToken(rawURL, token))
+	})
+}
+
+// PostJSON 请求 rawURL(不带 access_token), 用 JSON 编码 payload 作为请求体,
+// 重试策略与 Get 一致.
+func (c *WechatHTTPClient) PostJSON(rawURL string, payload interface{}) (body []byte, err error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return c.doWithRetry(func(token string) (*http.Response, error) {
+		return c.httpClient.Post(appendAccessToken(rawURL, token), "application/json; charset=utf-8", bytes.NewReader(data))
+	})
+}
+
+func (c *WechatHTTPClient) doWithRetry(do func(token string) (*http.Response, error)) (body []byte, err error) {
+	token, err := c.tokenService.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	body, errCode, err := c.doOnce(do, token)
+	if err != nil {
+		return nil, err
+	}
+	if !isInvalidTokenErrCode(errCode) {
+		return body, nil
+	}
+
+	token, err = c.refreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	body, _, err = c.doOnce(do, token)
+	return body, err
+}
+
+func (c *WechatHTTPClient) doOnce(do func(token string) (*http.Response, error), token string) (body []byte, errCode int, err error) {
+	httpResp, err := do(token)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer httpResp.Body.Close()
+
+	body, err = ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var envelope wechatErrorEnvelope
+	if err = json.Unmarshal(body, &envelope); err != nil {
+		// 不是期望的 JSON 格式(比如媒体文件下载), 直接把原始 body 交给调用方.
+		return body, 0, nil
+	}
+	return body, envelope.ErrCode, nil
+}
+
+// refreshToken 用 singleflight 合并同一个 WechatHTTPClient 上并发的刷新请求, 并且
+// 保证这个 client 两次真正的 TokenRefresh 调用之间至少间隔 minRefreshInterval 再加
+// 一点随机抖动, 避免多个 goroutine 在同一时刻都撞上过期错误时一起刷新.
+// singleflight 分组和刷新间隔都只在单个 WechatHTTPClient 内生效, 共用同一个
+// TokenService 的不同 WechatHTTPClient 之间的刷新不会被合并.
+func (c *WechatHTTPClient) refreshToken() (token string, err error) {
+	v, err, _ := c.refreshGroup.Do(c.appid, func() (interface{}, error) {
+		c.mutex.Lock()
+		elapsed := time.Since(c.lastRefresh)
+		interval := minRefreshInterval + time.Duration(rand.Int63n(int64(minRefreshInterval/2)+1))
+		if c.lastRefresh.IsZero() || elapsed >= interval {
+			c.lastRefresh = time.Now()
+			c.mutex.Unlock()
+			return c.tokenService.TokenRefresh()
+		}
+		c.mutex.Unlock()
+
+		// 间隔太短, 说明刚刚已经有人刷新过了, 直接读取当前缓存的 token 即可.
+		return c.tokenService.Token()
+	})
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+func isInvalidTokenErrCode(errCode int) bool {
+	switch errCode {
+	case errCodeInvalidAccessToken, errCodeIllegalAccessToken, errCodeExpiredAccessToken:
+		return true
+	default:
+		return false
+	}
+}
+
+func appendAccessToken(rawURL, token string) string {
+	if strings.ContainsRune(rawURL, '?') {
+		return rawURL + "&access_token=" + url.QueryEscape(token)
+	}
+	return rawURL + "?access_token=" + url.QueryEscape(token)
+}
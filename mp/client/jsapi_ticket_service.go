@@ -0,0 +1,127 @@
+package client
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+var _ JSAPITicketService = new(DefaultJSAPITicketService)
+
+// DefaultJSAPITicketService 是 JSAPITicketService 的默认实现, 结构和
+// DefaultTokenService 完全一致, 只是用 TokenService 换取 access_token,
+// 再用 access_token 换取 jsapi_ticket.
+type DefaultJSAPITicketService struct {
+	tokenService TokenService
+
+	// goroutine ticketAutoUpdate() 里有个定时器, 每次触发都会更新 currentTicket,
+	// 同时 goroutine ticketAutoUpdate() 监听 resetTicketRefreshTickChan,
+	// 如果有新的数据, 则重置定时器, 定时时间为 resetTicketRefreshTickChan 传过来的数据.
+	currentTicket struct {
+		rwmutex sync.RWMutex
+		ticket  string
+		err     error
+	}
+	resetTicketRefreshTickChan chan time.Duration
+
+	httpClient *WechatHTTPClient
+}
+
+func NewDefaultJSAPITicketService(tokenService TokenService, httpClient *http.Client) (srv *DefaultJSAPITicketService) {
+	srv = &DefaultJSAPITicketService{
+		tokenService:               tokenService,
+		resetTicketRefreshTickChan: make(chan time.Duration),
+		httpClient:                 NewWechatHTTPClient("jsapi_ticket", tokenService, httpClient),
+	}
+
+	resp, err := srv.getNewTicket()
+	if err != nil {
+		srv.currentTicket.ticket = ""
+		srv.currentTicket.err = err
+		go srv.ticketAutoUpdate(time.Minute) // 一分钟后尝试
+	} else {
+		srv.currentTicket.ticket = resp.Ticket
+		srv.currentTicket.err = nil
+		go srv.ticketAutoUpdate(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+	return
+}
+
+func (srv *DefaultJSAPITicketService) Ticket() (ticket string, err error) {
+	srv.currentTicket.rwmutex.RLock()
+	ticket = srv.currentTicket.ticket
+	err = srv.currentTicket.err
+	srv.currentTicket.rwmutex.RUnlock()
+	return
+}
+
+func (srv *DefaultJSAPITicketService) TicketRefresh() (ticket string, err error) {
+	srv.currentTicket.rwmutex.Lock()
+	defer srv.currentTicket.rwmutex.Unlock()
+
+	resp, err := srv.getNewTicket()
+	if err != nil {
+		srv.currentTicket.ticket = ""
+		srv.currentTicket.err = err
+		srv.resetTicketRefreshTickChan <- time.Minute // 一分钟后尝试
+		return
+	}
+
+	ticket = resp.Ticket
+
+	srv.currentTicket.ticket = resp.Ticket
+	srv.currentTicket.err = nil
+	srv.resetTicketRefreshTickChan <- time.Duration(resp.ExpiresIn) * time.Second
+	return
+}
+
+func (srv *DefaultJSAPITicketService) getNewTicket() (resp *ticketResponse, err error) {
+	return getNewTicket(srv.httpClient, "jsapi")
+}
+
+// 单独一个 goroutine 来定时获取 jsapi_ticket, 与 (*DefaultTokenService).tokenAutoUpdate
+// 完全相同的结构.
+func (srv *DefaultJSAPITicketService) ticketAutoUpdate(tickDuration time.Duration) {
+	const defaultTickDuration = time.Minute
+	var ticker *time.Ticker
+
+NEW_TICK_DURATION:
+	ticker = time.NewTicker(tickDuration)
+	for {
+		select {
+		case tickDuration = <-srv.resetTicketRefreshTickChan:
+			ticker.Stop()
+			goto NEW_TICK_DURATION
+
+		case <-ticker.C:
+			srv.currentTicket.rwmutex.Lock()
+
+			resp, err := srv.getNewTicket()
+			if err != nil {
+				srv.currentTicket.ticket = ""
+				srv.currentTicket.err = err
+
+				srv.currentTicket.rwmutex.Unlock()
+
+				if tickDuration != defaultTickDuration { // 出错则重置到 defaultTickDuration
+					ticker.Stop()
+					tickDuration = defaultTickDuration
+					goto NEW_TICK_DURATION
+				}
+
+			} else {
+				srv.currentTicket.ticket = resp.Ticket
+				srv.currentTicket.err = nil
+
+				srv.currentTicket.rwmutex.Unlock()
+
+				newTickDuration := time.Duration(resp.ExpiresIn) * time.Second
+				if tickDuration != newTickDuration {
+					ticker.Stop()
+					tickDuration = newTickDuration
+					goto NEW_TICK_DURATION
+				}
+			}
+		}
+	}
+}
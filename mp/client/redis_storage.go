@@ -0,0 +1,78 @@
+package client
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+var _ Storage = new(RedisStorage)
+
+// RedisStorage 是基于 Redis 的 Storage 实现, 用 SETNX + PX 实现带过期时间的分布式锁.
+type RedisStorage struct {
+	pool      *redis.Pool
+	keyPrefix string
+}
+
+// NewRedisStorage 创建一个新的 RedisStorage.
+// keyPrefix 建议按 appid 区分, 避免多个公众号共用一个 Redis 时互相覆盖.
+func NewRedisStorage(pool *redis.Pool, keyPrefix string) *RedisStorage {
+	return &RedisStorage{
+		pool:      pool,
+		keyPrefix: keyPrefix,
+	}
+}
+
+func (s *RedisStorage) tokenKey() string     { return s.keyPrefix + ":token" }
+func (s *RedisStorage) expiresAtKey() string { return s.keyPrefix + ":expires_at" }
+func (s *RedisStorage) lockKey() string      { return s.keyPrefix + ":lock" }
+
+func (s *RedisStorage) Get() (token string, expiresAt time.Time, err error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	reply, err := redis.Strings(conn.Do("MGET", s.tokenKey(), s.expiresAtKey()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if len(reply) != 2 || reply[0] == "" || reply[1] == "" {
+		return "", time.Time{}, nil
+	}
+
+	unix, err := strconv.ParseInt(reply[1], 10, 64)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return reply[0], time.Unix(unix, 0), nil
+}
+
+func (s *RedisStorage) Set(token string, expiresAt time.Time) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("MSET", s.tokenKey(), token, s.expiresAtKey(), expiresAt.Unix())
+	return err
+}
+
+func (s *RedisStorage) Lock(ttl time.Duration) (ok bool, err error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	reply, err := redis.String(conn.Do("SET", s.lockKey(), "1", "NX", "PX", ttl.Nanoseconds()/1e6))
+	if err != nil {
+		if err == redis.ErrNil {
+			return false, nil
+		}
+		return false, err
+	}
+	return reply == "OK", nil
+}
+
+func (s *RedisStorage) Unlock() error {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("DEL", s.lockKey())
+	return err
+}
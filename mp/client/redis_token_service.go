@@ -0,0 +1,13 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// NewRedisTokenService 创建一个用 Redis 在多进程/多机器之间共享 access token 的
+// TokenService, 本质是用 RedisStorage 支撑的 CachedTokenService.
+func NewRedisTokenService(pool *redis.Pool, appid, appsecret, keyPrefix string, httpClient *http.Client) *CachedTokenService {
+	return NewCachedTokenService(appid, appsecret, NewRedisStorage(pool, keyPrefix), httpClient)
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+var _ TokenStore = new(RedisTokenStore)
+
+// RedisTokenStore 把 access token 保存在 Redis 里, 用于单进程重启/滚动发布时
+// 不丢失 token; 如果想要多进程共享同一个 token 并互相协调刷新, 请使用
+// RedisStorage + CachedTokenService 而不是这个类型.
+type RedisTokenStore struct {
+	pool *redis.Pool
+	key  string
+}
+
+// NewRedisTokenStore 创建一个新的 RedisTokenStore, key 建议按 appid 区分.
+func NewRedisTokenStore(pool *redis.Pool, key string) *RedisTokenStore {
+	return &RedisTokenStore{pool: pool, key: key}
+}
+
+func (s *RedisTokenStore) Load() (token string, expiresAt time.Time, err error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	reply, err := redis.String(conn.Do("GET", s.key))
+	if err == redis.ErrNil {
+		return "", time.Time{}, nil
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	idx := strings.LastIndexByte(reply, '|')
+	if idx < 0 {
+		return "", time.Time{}, nil
+	}
+	unix, err := strconv.ParseInt(reply[idx+1:], 10, 64)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return reply[:idx], time.Unix(unix, 0), nil
+}
+
+func (s *RedisTokenStore) Save(token string, expiresAt time.Time) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	value := token + "|" + strconv.FormatInt(expiresAt.Unix(), 10)
+	_, err := conn.Do("SET", s.key, value)
+	return err
+}
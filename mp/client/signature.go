@@ -0,0 +1,65 @@
+package client
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Signature 是 wx.config 需要的 jsapi 签名, noncestr/timestamp/signature 三者
+// 要原样传给前端 JS-SDK.
+type Signature struct {
+	NonceStr  string `json:"noncestr"`
+	Timestamp int64  `json:"timestamp"`
+	Signature string `json:"signature"`
+}
+
+// JSAPISignature 用当前的 jsapi_ticket 对 url 进行签名, 签名算法见
+// https://mp.weixin.qq.com/wiki?t=resource/res_main&id=mp1421141115
+func (srv *DefaultJSAPITicketService) JSAPISignature(url string) (sign Signature, err error) {
+	ticket, err := srv.Ticket()
+	if err != nil {
+		return Signature{}, err
+	}
+
+	nonceStr, err := randomNonceStr()
+	if err != nil {
+		return Signature{}, err
+	}
+	timestamp := time.Now().Unix()
+
+	sign = Signature{
+		NonceStr:  nonceStr,
+		Timestamp: timestamp,
+		Signature: signJSAPI(ticket, nonceStr, timestamp, url),
+	}
+	return sign, nil
+}
+
+// signJSAPI 对 jsapi_ticket, noncestr, timestamp, url 按字段名做字典序排序后
+// 拼接成一个字符串, 再做 sha1.
+func signJSAPI(ticket, nonceStr string, timestamp int64, url string) string {
+	pairs := []string{
+		"jsapi_ticket=" + ticket,
+		"noncestr=" + nonceStr,
+		"timestamp=" + fmt.Sprintf("%d", timestamp),
+		"url=" + url,
+	}
+	sort.Strings(pairs)
+
+	h := sha1.New()
+	h.Write([]byte(strings.Join(pairs, "&")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func randomNonceStr() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+var _ Storage = new(SQLStorage)
+
+// SQLStorage 是基于 database/sql 的 Storage 实现, 每个 appid 在表里占一行,
+// 用 `SELECT ... FOR UPDATE` 把行锁当成分布式锁使用.
+//
+// 使用前需要自己建表, 表结构可参考(以 MySQL 为例):
+//
+//	CREATE TABLE wechat_token (
+//		appid      VARCHAR(32)  NOT NULL PRIMARY KEY,
+//		token      VARCHAR(256) NOT NULL DEFAULT '',
+//		expires_at BIGINT       NOT NULL DEFAULT 0
+//	);
+//	CREATE TABLE wechat_token_lock (
+//		appid        VARCHAR(32) NOT NULL PRIMARY KEY,
+//		locked_until BIGINT      NOT NULL DEFAULT 0
+//	);
+type SQLStorage struct {
+	db    *sql.DB
+	appid string
+	table string
+}
+
+// NewSQLStorage 创建一个新的 SQLStorage, table 为空时默认使用 "wechat_token".
+func NewSQLStorage(db *sql.DB, appid, table string) *SQLStorage {
+	if table == "" {
+		table = "wechat_token"
+	}
+	return &SQLStorage{
+		db:    db,
+		appid: appid,
+		table: table,
+	}
+}
+
+func (s *SQLStorage) Get() (token string, expiresAt time.Time, err error) {
+	var unix int64
+	err = s.db.QueryRow("SELECT token, expires_at FROM "+s.table+" WHERE appid = ?", s.appid).
+		Scan(&token, &unix)
+	switch err {
+	case nil:
+		return token, time.Unix(unix, 0), nil
+	case sql.ErrNoRows:
+		return "", time.Time{}, nil
+	default:
+		return "", time.Time{}, err
+	}
+}
+
+func (s *SQLStorage) Set(token string, expiresAt time.Time) error {
+	_, err := s.db.Exec(
+		"INSERT INTO "+s.table+" (appid, token, expires_at) VALUES (?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE token = VALUES(token), expires_at = VALUES(expires_at)",
+		s.appid, token, expiresAt.Unix(),
+	)
+	return err
+}
+
+// Lock 用一个 <table>_lock 表(默认 wechat_token_lock)保存锁的持有截止时间, 通过 `SELECT ... FOR UPDATE` 锁住
+// 这一行再判断、更新截止时间, 模拟出带 TTL 的分布式锁.
+func (s *SQLStorage) Lock(ttl time.Duration) (ok bool, err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var lockedUntil int64
+	err = tx.QueryRow(
+		"SELECT locked_until FROM "+s.table+"_lock WHERE appid = ? FOR UPDATE", s.appid,
+	).Scan(&lockedUntil)
+
+	now := time.Now()
+	switch err {
+	case sql.ErrNoRows:
+		if _, err = tx.Exec(
+			"INSERT INTO "+s.table+"_lock (appid, locked_until) VALUES (?, ?)",
+			s.appid, now.Add(ttl).Unix(),
+		); err != nil {
+			// appid 对应的行这一刻还不存在时, 两个实例可能同时 SELECT ... FOR UPDATE
+			// 拿到 sql.ErrNoRows 然后都去 INSERT, 输的那个会撞主键冲突 —— 这和平时
+			// "锁还在其他实例手里"是同一件事, 当作抢锁失败处理, 不能当成硬错误返回,
+			// 否则 loadOrRefreshUncombined 会把这次刷新当成致命错误直接放弃重试.
+			if isDuplicateKeyErr(err) {
+				return false, nil
+			}
+			return false, err
+		}
+	case nil:
+		if lockedUntil > now.Unix() {
+			return false, nil // 锁还在其他实例手里
+		}
+		if _, err = tx.Exec(
+			"UPDATE "+s.table+"_lock SET locked_until = ? WHERE appid = ?",
+			now.Add(ttl).Unix(), s.appid,
+		); err != nil {
+			return false, err
+		}
+	default:
+		return false, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *SQLStorage) Unlock() error {
+	_, err := s.db.Exec("UPDATE "+s.table+"_lock SET locked_until = 0 WHERE appid = ?", s.appid)
+	return err
+}
+
+// isDuplicateKeyErr 判断 err 是不是主键/唯一键冲突, 目前只认 MySQL 的错误信息
+// (建表注释里给的就是 MySQL 的例子), 换其他数据库需要相应扩展.
+func isDuplicateKeyErr(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "Duplicate entry")
+}
@@ -0,0 +1,13 @@
+package client
+
+import (
+	"database/sql"
+	"net/http"
+)
+
+// NewSQLTokenService 创建一个用 database/sql 在多进程/多机器之间共享 access token 的
+// TokenService, 本质是用 SQLStorage 支撑的 CachedTokenService. table 为空时默认
+// 使用 SQLStorage 的默认表名.
+func NewSQLTokenService(db *sql.DB, appid, appsecret, table string, httpClient *http.Client) *CachedTokenService {
+	return NewCachedTokenService(appid, appsecret, NewSQLStorage(db, appid, table), httpClient)
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"database/sql"
+	"time"
+)
+
+var _ TokenStore = new(SQLTokenStore)
+
+// SQLTokenStore 把 access token 保存在 database/sql 的一张表里, 用于单进程
+// 重启/滚动发布时不丢失 token. 表结构可参考(以 MySQL 为例):
+//
+//	CREATE TABLE wechat_token (
+//		appid      VARCHAR(32)  NOT NULL PRIMARY KEY,
+//		token      VARCHAR(256) NOT NULL DEFAULT '',
+//		expires_at BIGINT       NOT NULL DEFAULT 0
+//	);
+//
+// 可以和 SQLStorage 共用同一张表, 两者互不干扰(SQLTokenStore 不使用
+// wechat_token_lock 表).
+type SQLTokenStore struct {
+	db    *sql.DB
+	appid string
+	table string
+}
+
+// NewSQLTokenStore 创建一个新的 SQLTokenStore, table 为空时默认使用 "wechat_token".
+func NewSQLTokenStore(db *sql.DB, appid, table string) *SQLTokenStore {
+	if table == "" {
+		table = "wechat_token"
+	}
+	return &SQLTokenStore{db: db, appid: appid, table: table}
+}
+
+func (s *SQLTokenStore) Load() (token string, expiresAt time.Time, err error) {
+	var unix int64
+	err = s.db.QueryRow("SELECT token, expires_at FROM "+s.table+" WHERE appid = ?", s.appid).
+		Scan(&token, &unix)
+	switch err {
+	case nil:
+		return token, time.Unix(unix, 0), nil
+	case sql.ErrNoRows:
+		return "", time.Time{}, nil
+	default:
+		return "", time.Time{}, err
+	}
+}
+
+func (s *SQLTokenStore) Save(token string, expiresAt time.Time) error {
+	_, err := s.db.Exec(
+		"INSERT INTO "+s.table+" (appid, token, expires_at) VALUES (?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE token = VALUES(token), expires_at = VALUES(expires_at)",
+		s.appid, token, expiresAt.Unix(),
+	)
+	return err
+}
@@ -0,0 +1,27 @@
+package client
+
+import (
+	"time"
+)
+
+// Storage 是 CachedTokenService 依赖的外部存储抽象, 用于在多个进程/多台机器之间
+// 共享同一个 appid 的 access token, 避免每个实例各自去刷新而撞上 2000次/日 的配额上限.
+//
+// 实现者需要保证 Lock 具有超时时间(TTL), 即使持有锁的进程异常退出(panic, 被杀死),
+// 锁也能在 ttl 之后自动释放, 不会出现所有进程都拿不到 access token 的死锁情况.
+type Storage interface {
+	// Get 返回当前缓存的 access token 以及它的过期时间点.
+	// 如果缓存里还没有数据, 应返回 token == "", err == nil.
+	Get() (token string, expiresAt time.Time, err error)
+
+	// Set 把新的 access token 写入缓存, expiresAt 是这个 token 的过期时间点.
+	Set(token string, expiresAt time.Time) error
+
+	// Lock 尝试获取一个集群范围内唯一的刷新锁(leader), ttl 是锁的过期时间.
+	// 抢到锁返回 ok == true; 锁已被其他进程持有则返回 ok == false, err == nil.
+	Lock(ttl time.Duration) (ok bool, err error)
+
+	// Unlock 释放 Lock 获取到的锁. 调用者应该在 Set 完成之后再 Unlock,
+	// 避免 Unlock 和其他进程抢到锁之间出现读到旧 token 的窗口.
+	Unlock() error
+}
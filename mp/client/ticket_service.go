@@ -0,0 +1,81 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// jsapi_ticket 伺服接口, 用法和语义都与 TokenService 一致.
+type JSAPITicketService interface {
+	// Ticket 获取 jsapi_ticket, 该 ticket 一般缓存在某个地方.
+	Ticket() (ticket string, err error)
+
+	// TicketRefresh 从微信服务器获取新的 jsapi_ticket, 参考 TokenService.TokenRefresh
+	// 的注意事项, 正常情况下无需调用.
+	TicketRefresh() (ticket string, err error)
+}
+
+// wx.card ticket 伺服接口, 用法和语义都与 TokenService 一致.
+type CardTicketService interface {
+	// Ticket 获取 wx_card ticket, 该 ticket 一般缓存在某个地方.
+	Ticket() (ticket string, err error)
+
+	// TicketRefresh 从微信服务器获取新的 wx_card ticket, 参考 TokenService.TokenRefresh
+	// 的注意事项, 正常情况下无需调用.
+	TicketRefresh() (ticket string, err error)
+}
+
+// 从微信服务器获取 ticket 成功时返回的消息格式, jsapi 和 wx_card 共用同一个格式.
+type ticketResponse struct {
+	Ticket    string `json:"ticket"`     // 获取到的 ticket
+	ExpiresIn int64  `json:"expires_in"` // ticket 有效时间, 单位: 秒
+}
+
+// getNewTicket 从微信服务器获取新的 ticket, ticketType 是 "jsapi" 或者 "wx_card".
+// 通过 WechatHTTPClient 发请求, 这样 access_token 在请求之间失效(40001/42001)
+// 时会自动刷新重试一次, 不需要在这里单独处理; 使用和 (*DefaultTokenService).getNewToken
+// 完全相同的缓冲区策略.
+func getNewTicket(httpClient *WechatHTTPClient, ticketType string) (resp *ticketResponse, err error) {
+	body, err := httpClient.Get("https://api.weixin.qq.com/cgi-bin/ticket/getticket?type=" + ticketType)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		ticketResponse
+		Error
+	}
+	if err = json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	if result.ErrCode != 0 {
+		return nil, &result.Error
+	}
+
+	// 由于网络的延时, ticket 过期时间留了一个缓冲区, 策略和 getNewToken 一致;
+	// 正常情况下微信服务器会返回 7200, 则缓冲区的大小为 10 分钟.
+	switch {
+	case result.ExpiresIn > 60*60: // 返回的过期时间大于 1 个小时, 缓冲区为 10 分钟
+		result.ExpiresIn -= 60 * 10
+		resp = &result.ticketResponse
+
+	case result.ExpiresIn > 60*30: // 返回的过期时间大于 30 分钟, 缓冲区为 5 分钟
+		result.ExpiresIn -= 60 * 5
+		resp = &result.ticketResponse
+
+	case result.ExpiresIn > 60*5: // 返回的过期时间大于 5 分钟, 缓冲区为 1 分钟
+		result.ExpiresIn -= 60
+		resp = &result.ticketResponse
+
+	case result.ExpiresIn > 60: // 返回的过期时间大于 1 分钟, 缓冲区为 10 秒
+		result.ExpiresIn -= 10
+		resp = &result.ticketResponse
+
+	case result.ExpiresIn > 0: // 没有办法了, 死马当做活马医了
+		resp = &result.ticketResponse
+
+	default:
+		err = fmt.Errorf("expires_in 应该是正整数, 现在为: %d", result.ExpiresIn)
+	}
+	return
+}
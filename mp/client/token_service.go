@@ -33,8 +33,14 @@ type TokenService interface {
 
 var _ TokenService = new(DefaultTokenService)
 
+// minStoredTokenLifetime 是判断 TokenStore.Load() 取回的 token 是否还"有意义"的
+// 门槛, 低于这个剩余时间就当作过期处理, 直接去微信服务器换一个新的;
+// 这个门槛等于 getNewToken 里 "缓冲区为 1 分钟" 那一档的缓冲区大小.
+const minStoredTokenLifetime = time.Minute
+
 type DefaultTokenService struct {
 	appid, appsecret string
+	tokenStore       TokenStore // 可以为 nil, 此时退化为不持久化, 行为和原来一样
 
 	// goroutine tokenAutoUpdate() 里有个定时器, 每次触发都会更新 currentToken,
 	// 同时 goroutine tokenAutoUpdate() 监听 resetTokenRefreshTickChan,
@@ -49,10 +55,16 @@ type DefaultTokenService struct {
 	httpClient *http.Client
 }
 
-func NewDefaultTokenService(appid, appsecret string, httpClient *http.Client) (srv *DefaultTokenService) {
+// NewDefaultTokenService 创建一个新的 DefaultTokenService.
+// tokenStore 为 nil 时不做任何持久化, 每次启动都会去微信服务器换一个新的 access token,
+// 和旧版本的行为完全一致; 传入 FileTokenStore/RedisTokenStore/SQLTokenStore 等实现后,
+// 启动时会先尝试 Load(), 如果读到的 token 还有意义的剩余寿命就直接复用, 跳过这次
+// getNewToken 调用, 这样重启/滚动发布不会额外消耗 2000次/日 的配额.
+func NewDefaultTokenService(appid, appsecret string, tokenStore TokenStore, httpClient *http.Client) (srv *DefaultTokenService) {
 	srv = &DefaultTokenService{
 		appid:                     appid,
 		appsecret:                 appsecret,
+		tokenStore:                tokenStore,
 		resetTokenRefreshTickChan: make(chan time.Duration),
 	}
 
@@ -62,6 +74,13 @@ func NewDefaultTokenService(appid, appsecret string, httpClient *http.Client) (s
 		srv.httpClient = httpClient
 	}
 
+	if token, _, tickDuration, ok := srv.loadFromStore(); ok {
+		srv.currentToken.token = token
+		srv.currentToken.err = nil
+		go srv.tokenAutoUpdate(tickDuration)
+		return
+	}
+
 	tk, err := srv.getNewToken()
 	if err != nil {
 		srv.currentToken.token = ""
@@ -70,11 +89,41 @@ func NewDefaultTokenService(appid, appsecret string, httpClient *http.Client) (s
 	} else {
 		srv.currentToken.token = tk.Token
 		srv.currentToken.err = nil
+		srv.saveToStore(tk.Token, time.Duration(tk.ExpiresIn)*time.Second)
 		go srv.tokenAutoUpdate(time.Duration(tk.ExpiresIn) * time.Second)
 	}
 	return
 }
 
+// loadFromStore 尝试从 tokenStore 里读取之前保存的 token, 只有剩余寿命大于
+// minStoredTokenLifetime 才会被当作可用, 并返回应该给 tokenAutoUpdate 用的
+// 定时器时长(也就是剩余寿命).
+func (srv *DefaultTokenService) loadFromStore() (token string, expiresAt time.Time, tickDuration time.Duration, ok bool) {
+	if srv.tokenStore == nil {
+		return "", time.Time{}, 0, false
+	}
+
+	token, expiresAt, err := srv.tokenStore.Load()
+	if err != nil || token == "" {
+		return "", time.Time{}, 0, false
+	}
+
+	remaining := time.Until(expiresAt)
+	if remaining <= minStoredTokenLifetime {
+		return "", time.Time{}, 0, false
+	}
+	return token, expiresAt, remaining, true
+}
+
+// saveToStore 把新换取到的 token 写入 tokenStore, 这里不关心写入是否成功,
+// 持久化失败只是退化成下次重启要重新走一次 getNewToken, 不影响当前进程的可用性.
+func (srv *DefaultTokenService) saveToStore(token string, ttl time.Duration) {
+	if srv.tokenStore == nil {
+		return
+	}
+	srv.tokenStore.Save(token, time.Now().Add(ttl))
+}
+
 func (srv *DefaultTokenService) Token() (token string, err error) {
 	srv.currentToken.rwmutex.RLock()
 	token = srv.currentToken.token
@@ -99,6 +148,7 @@ func (srv *DefaultTokenService) TokenRefresh() (token string, err error) {
 
 	srv.currentToken.token = resp.Token
 	srv.currentToken.err = nil
+	srv.saveToStore(resp.Token, time.Duration(resp.ExpiresIn)*time.Second)
 	srv.resetTokenRefreshTickChan <- time.Duration(resp.ExpiresIn) * time.Second
 	return
 }
@@ -111,10 +161,16 @@ type tokenResponse struct {
 
 // 从微信服务器获取新的 access_token
 func (srv *DefaultTokenService) getNewToken() (resp *tokenResponse, err error) {
+	return getNewToken(srv.httpClient, srv.appid, srv.appsecret)
+}
+
+// getNewToken 是 (*DefaultTokenService).getNewToken 的无状态版本, 方便其他 TokenService
+// 实现(比如 CachedTokenService)复用同一套请求和缓冲区逻辑.
+func getNewToken(httpClient *http.Client, appid, appsecret string) (resp *tokenResponse, err error) {
 	_url := "https://api.weixin.qq.com/cgi-bin/token?grant_type=client_credential&appid=" +
-		srv.appid + "&secret=" + srv.appsecret
+		appid + "&secret=" + appsecret
 
-	httpResp, err := srv.httpClient.Get(_url)
+	httpResp, err := httpClient.Get(_url)
 	if err != nil {
 		return
 	}
@@ -201,6 +257,8 @@ NEW_TICK_DURATION:
 
 				srv.currentToken.rwmutex.Unlock()
 
+				srv.saveToStore(resp.Token, time.Duration(resp.ExpiresIn)*time.Second)
+
 				newTickDuration := time.Duration(resp.ExpiresIn) * time.Second
 				if tickDuration != newTickDuration {
 					ticker.Stop()
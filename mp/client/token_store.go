@@ -0,0 +1,15 @@
+package client
+
+import "time"
+
+// TokenStore 是 DefaultTokenService 用来持久化单个 access token 的接口,
+// 和 Storage 不同, TokenStore 只管一个进程自己的 token 落盘/落库, 不涉及
+// 跨进程抢锁; 跨进程共享 token 请使用 CachedTokenService 和 Storage.
+type TokenStore interface {
+	// Load 读取上一次保存的 access token 和它的过期时间点.
+	// 如果之前从来没有保存过, 应返回 token == "", err == nil.
+	Load() (token string, expiresAt time.Time, err error)
+
+	// Save 把新的 access token 和过期时间点保存下来, 覆盖上一次保存的内容.
+	Save(token string, expiresAt time.Time) error
+}
@@ -0,0 +1,68 @@
+package oauth2_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/chenghuama/wechat/oauth2"
+)
+
+// 这个例子演示了如何用 LoginHandler 串起网页授权的跳转/回调流程, 而不用自己
+// 手工拼接 AuthCodeURL 和解析 state/code 这些细节.
+func Example_loginHandler() {
+	client := oauth2.NewClient("APPID", "APPSECRET", nil)
+
+	var gotState string
+	handler := &oauth2.LoginHandler{
+		Client:      client,
+		RedirectURL: "https://example.com/wechat/callback",
+		Scope:       "snsapi_base",
+		OnSuccess: func(w http.ResponseWriter, r *http.Request, token *oauth2.Token, userInfo *oauth2.UserInfo) {
+			fmt.Fprintf(w, "openid=%s", token.OpenId)
+		},
+	}
+
+	appServer := httptest.NewServer(handler)
+	defer appServer.Close()
+
+	httpClient := appServer.Client()
+	httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	// 第一步: 浏览器首次访问 handler, 会被 302 跳转到微信授权页面, state 由
+	// handler 内置的 CookieStateStore 生成, 并附带在跳转 URL 上.
+	resp, err := httpClient.Get(appServer.URL)
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	resp.Body.Close()
+
+	loc, err := resp.Location()
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	gotState = loc.Query().Get("state")
+	fmt.Println("redirected with state:", gotState != "")
+
+	// 第二步: 校验一个伪造的 state 会被拒绝.
+	forged := appServer.URL + "?" + url.Values{
+		"code":  {"CODE"},
+		"state": {"forged-state"},
+	}.Encode()
+	resp, err = httpClient.Get(forged)
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	resp.Body.Close()
+	fmt.Println("forged state rejected:", resp.StatusCode != http.StatusOK)
+
+	// Output:
+	// redirected with state: true
+	// forged state rejected: true
+}
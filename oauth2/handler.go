@@ -0,0 +1,98 @@
+package oauth2
+
+import (
+	"crypto/rand"
+	"net/http"
+	"sync"
+)
+
+// LoginHandler 是网页授权登录的 http.Handler, 封装了完整的跳转/回调流程:
+//
+//  1. 首次访问(没有 code 参数): 生成 state, 302 跳转到微信授权页面.
+//  2. 微信跳转回来(带 code/state 参数): 校验 state, 用 code 换取 token,
+//     如果 Scope 是 snsapi_userinfo 还会拉取用户信息, 然后调用 OnSuccess.
+//  3. 任何一步出错都会调用 OnError, 默认写一个 500 响应.
+type LoginHandler struct {
+	Client      *Client
+	RedirectURL string // 微信跳转回来的地址, 通常就是这个 handler 自己的完整 URL
+	Scope       string // snsapi_base 或者 snsapi_userinfo
+	Lang        string // UserInfo 使用的语言, 默认 zh_CN
+
+	StateStore StateStore // 为空时使用 CookieStateStore(随机 secret, 10 分钟有效期)
+
+	OnSuccess func(w http.ResponseWriter, r *http.Request, token *Token, userInfo *UserInfo)
+	OnError   func(w http.ResponseWriter, r *http.Request, err error)
+
+	// defaultStateStoreOnce/defaultStateStore 保证在 StateStore 留空时, 多个
+	// goroutine 并发调用 ServeHTTP 也只会生成并使用同一个 CookieStateStore
+	// (同一份 secret), 否则不同请求拿到的 store 可能各自持有不同的 secret,
+	// state 校验会随机失败.
+	defaultStateStoreOnce sync.Once
+	defaultStateStore     *CookieStateStore
+}
+
+func (h *LoginHandler) stateStore() StateStore {
+	if h.StateStore != nil {
+		return h.StateStore
+	}
+	h.defaultStateStoreOnce.Do(func() {
+		h.defaultStateStore = NewCookieStateStore(randomSecret(), 0)
+	})
+	return h.defaultStateStore
+}
+
+// randomSecret 在调用方没有显式提供 StateStore 时, 给默认的 CookieStateStore
+// 生成一个进程内的随机密钥.
+func randomSecret() []byte {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return b
+}
+
+func (h *LoginHandler) onError(w http.ResponseWriter, r *http.Request, err error) {
+	if h.OnError != nil {
+		h.OnError(w, r, err)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+func (h *LoginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	code := query.Get("code")
+
+	if code == "" {
+		state, err := h.stateStore().Generate(w, r)
+		if err != nil {
+			h.onError(w, r, err)
+			return
+		}
+		http.Redirect(w, r, h.Client.AuthCodeURL(h.RedirectURL, h.Scope, state), http.StatusFound)
+		return
+	}
+
+	state := query.Get("state")
+	if err := h.stateStore().Verify(w, r, state); err != nil {
+		h.onError(w, r, err)
+		return
+	}
+
+	token, err := h.Client.Exchange(r.Context(), code)
+	if err != nil {
+		h.onError(w, r, err)
+		return
+	}
+
+	var userInfo *UserInfo
+	if h.Scope == "snsapi_userinfo" {
+		userInfo, err = h.Client.UserInfo(r.Context(), token, h.Lang)
+		if err != nil {
+			h.onError(w, r, err)
+			return
+		}
+	}
+
+	if h.OnSuccess != nil {
+		h.OnSuccess(w, r, token, userInfo)
+	}
+}
@@ -0,0 +1,194 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/chanxuehong/wechat for the canonical source repository
+// @license     https://github.com/chanxuehong/wechat/blob/master/LICENSE
+// @authors     chanxuehong(chanxuehong@gmail.com)
+
+// Package oauth2 把 sns_url.go 里裸露的 URL 拼接函数包装成一套完整的网页授权
+// (sns/oauth2) 登录子系统, 接口形状参照 golang.org/x/oauth2.
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/chenghuama/wechat"
+)
+
+// Token 是网页授权拿到的 access_token, 同时携带 openid, 因为后续大部分接口
+// (比如 UserInfo)都要用到它.
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	OpenId       string    `json:"openid"`
+	Scope        string    `json:"scope"`
+	ExpiresAt    time.Time `json:"-"`
+}
+
+// Expired 判断 token 是否已经过期(或者即将过期).
+func (t *Token) Expired() bool {
+	if t == nil || t.AccessToken == "" {
+		return true
+	}
+	return !t.ExpiresAt.After(time.Now().Add(time.Minute))
+}
+
+// Error 是微信服务器返回的错误信息.
+type Error struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("errcode: %d, errmsg: %s", e.ErrCode, e.ErrMsg)
+}
+
+// UserInfo 是 /sns/userinfo 接口(scope=snsapi_userinfo)返回的用户信息.
+type UserInfo struct {
+	OpenId     string   `json:"openid"`
+	Nickname   string   `json:"nickname"`
+	Sex        int      `json:"sex"`
+	Province   string   `json:"province"`
+	City       string   `json:"city"`
+	Country    string   `json:"country"`
+	HeadImgURL string   `json:"headimgurl"`
+	Privilege  []string `json:"privilege"`
+	UnionId    string   `json:"unionid"`
+}
+
+// Client 是网页授权登录的客户端, 对应一个公众号的 appid/appsecret.
+type Client struct {
+	AppId      string
+	AppSecret  string
+	HTTPClient *http.Client
+}
+
+// NewClient 创建一个新的 Client.
+func NewClient(appid, appsecret string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		AppId:      appid,
+		AppSecret:  appsecret,
+		HTTPClient: httpClient,
+	}
+}
+
+// AuthCodeURL 生成跳转到微信授权页面的 URL, redirectURL 是用户确认授权后
+// 微信跳转回来的地址.
+func (c *Client) AuthCodeURL(redirectURL, scope, state string) string {
+	return wechat.SnsOAuth2AuthURL(c.AppId, redirectURL, scope, state)
+}
+
+// Exchange 用网页授权回调拿到的 code 换取 access_token/refresh_token/openid.
+func (c *Client) Exchange(ctx context.Context, code string) (*Token, error) {
+	_url := wechat.SnsOAuth2TokenURL(c.AppId, c.AppSecret, code)
+	return c.requestToken(ctx, _url)
+}
+
+// TokenSource 返回一个 oauth2.TokenSource, Token() 方法在 refresh_token
+// 还没过期的前提下, 按需用 refresh_token 换取新的 access_token.
+func (c *Client) TokenSource(refreshToken string) TokenSource {
+	return &refreshTokenSource{
+		client:       c,
+		refreshToken: refreshToken,
+	}
+}
+
+// UserInfo 拉取用户的基本信息, 需要用户在授权时同意了 snsapi_userinfo scope.
+func (c *Client) UserInfo(ctx context.Context, token *Token, lang string) (*UserInfo, error) {
+	if lang == "" {
+		lang = "zh_CN"
+	}
+	_url := wechat.SnsUserInfoURL(token.AccessToken, token.OpenId, lang)
+
+	req, err := http.NewRequest(http.MethodGet, _url, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := c.HTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("http.Status: %s", httpResp.Status)
+	}
+
+	var result struct {
+		UserInfo
+		Error
+	}
+	if err = json.NewDecoder(httpResp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.ErrCode != 0 {
+		return nil, &result.Error
+	}
+	return &result.UserInfo, nil
+}
+
+// requestToken 请求 sns/oauth2 的 access_token 或 refresh_token 接口,
+// 两者返回的消息格式是一样的.
+func (c *Client) requestToken(ctx context.Context, _url string) (*Token, error) {
+	req, err := http.NewRequest(http.MethodGet, _url, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := c.HTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("http.Status: %s", httpResp.Status)
+	}
+
+	var result struct {
+		Token
+		ExpiresIn int64 `json:"expires_in"`
+		Error
+	}
+	if err = json.NewDecoder(httpResp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.ErrCode != 0 {
+		return nil, &result.Error
+	}
+
+	result.Token.ExpiresAt = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	return &result.Token, nil
+}
+
+// TokenSource 接口形状与 golang.org/x/oauth2.TokenSource 一致, 方便熟悉
+// x/oauth2 的用户直接上手.
+type TokenSource interface {
+	Token() (*Token, error)
+}
+
+type refreshTokenSource struct {
+	client       *Client
+	refreshToken string
+	token        *Token
+}
+
+func (s *refreshTokenSource) Token() (*Token, error) {
+	if !s.token.Expired() {
+		return s.token, nil
+	}
+	_url := wechat.SnsOAuth2RefreshTokenURL(s.client.AppId, s.refreshToken)
+	token, err := s.client.requestToken(context.Background(), _url)
+	if err != nil {
+		return nil, err
+	}
+	if token.RefreshToken != "" {
+		s.refreshToken = token.RefreshToken
+	}
+	s.token = token
+	return token, nil
+}
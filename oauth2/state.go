@@ -0,0 +1,165 @@
+package oauth2
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrInvalidState 在 state 校验失败(过期, 伪造, 或者根本没见过)时返回.
+var ErrInvalidState = errors.New("oauth2: invalid state")
+
+// StateStore 负责生成、校验 OAuth2 的 state 参数, 防止 CSRF.
+type StateStore interface {
+	// Generate 为一次授权请求生成 state, 同时可以把它写到 ResponseWriter 里
+	// (比如种下一个 cookie).
+	Generate(w http.ResponseWriter, r *http.Request) (state string, err error)
+
+	// Verify 在回调里校验 state 是否合法.
+	Verify(w http.ResponseWriter, r *http.Request, state string) error
+}
+
+var _ StateStore = new(MemoryStateStore)
+
+// MemoryStateStore 把生成的 state 保存在进程内存里, 过期后自动失效,
+// 只适合单进程部署.
+type MemoryStateStore struct {
+	ttl time.Duration
+
+	mutex  sync.Mutex
+	states map[string]time.Time // state => 过期时间
+}
+
+// NewMemoryStateStore 创建一个新的 MemoryStateStore, ttl 是 state 的有效期.
+func NewMemoryStateStore(ttl time.Duration) *MemoryStateStore {
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	return &MemoryStateStore{
+		ttl:    ttl,
+		states: make(map[string]time.Time),
+	}
+}
+
+func (s *MemoryStateStore) Generate(w http.ResponseWriter, r *http.Request) (string, error) {
+	state, err := randomState()
+	if err != nil {
+		return "", err
+	}
+
+	s.mutex.Lock()
+	s.gcLocked()
+	s.states[state] = time.Now().Add(s.ttl)
+	s.mutex.Unlock()
+	return state, nil
+}
+
+func (s *MemoryStateStore) Verify(w http.ResponseWriter, r *http.Request, state string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	expiresAt, ok := s.states[state]
+	if !ok {
+		return ErrInvalidState
+	}
+	delete(s.states, state) // state 只能用一次
+
+	if time.Now().After(expiresAt) {
+		return ErrInvalidState
+	}
+	return nil
+}
+
+// gcLocked 清理过期的 state, 调用者需要持有 s.mutex.
+func (s *MemoryStateStore) gcLocked() {
+	now := time.Now()
+	for state, expiresAt := range s.states {
+		if now.After(expiresAt) {
+			delete(s.states, state)
+		}
+	}
+}
+
+var _ StateStore = new(CookieStateStore)
+
+// CookieStateStore 是默认的 StateStore 实现: state 本身是一个带 HMAC 签名的
+// 随机串, 校验时只需要重新计算签名比对, 不需要任何服务端存储.
+type CookieStateStore struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewCookieStateStore 创建一个新的 CookieStateStore, secret 用于签名.
+func NewCookieStateStore(secret []byte, ttl time.Duration) *CookieStateStore {
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	return &CookieStateStore{secret: secret, ttl: ttl}
+}
+
+func (s *CookieStateStore) Generate(w http.ResponseWriter, r *http.Request) (string, error) {
+	nonce, err := randomState()
+	if err != nil {
+		return "", err
+	}
+	expiresAt := time.Now().Add(s.ttl).Unix()
+	payload := fmt.Sprintf("%s.%d", nonce, expiresAt)
+	sig := s.sign(payload)
+	return payload + "." + sig, nil
+}
+
+func (s *CookieStateStore) Verify(w http.ResponseWriter, r *http.Request, state string) error {
+	parts := splitState(state)
+	if len(parts) != 3 {
+		return ErrInvalidState
+	}
+	nonce, expiresAtStr, sig := parts[0], parts[1], parts[2]
+
+	payload := nonce + "." + expiresAtStr
+	if !hmac.Equal([]byte(sig), []byte(s.sign(payload))) {
+		return ErrInvalidState
+	}
+
+	var expiresAtUnix int64
+	if _, err := fmt.Sscanf(expiresAtStr, "%d", &expiresAtUnix); err != nil {
+		return ErrInvalidState
+	}
+	if time.Now().Unix() > expiresAtUnix {
+		return ErrInvalidState
+	}
+	return nil
+}
+
+func (s *CookieStateStore) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func splitState(state string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(state); i++ {
+		if state[i] == '.' {
+			parts = append(parts, state[start:i])
+			start = i + 1
+		}
+	}
+	parts = append(parts, state[start:])
+	return parts
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
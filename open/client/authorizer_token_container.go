@@ -0,0 +1,68 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// AuthorizerTokenServiceContainer 是一个按 authorizer_appid 索引的
+// AuthorizerTokenService 容器, 一个第三方平台下面通常挂着成百上千个被授权的
+// 公众号/小程序, 没有必要(也不现实)为每一个都单独持有一个变量.
+type AuthorizerTokenServiceContainer struct {
+	componentTokenService ComponentTokenService
+	componentAppId        string
+	httpClient            *http.Client
+
+	rwmutex  sync.RWMutex
+	services map[string]AuthorizerTokenService // authorizer_appid => AuthorizerTokenService
+}
+
+// NewAuthorizerTokenServiceContainer 创建一个新的容器.
+func NewAuthorizerTokenServiceContainer(componentTokenService ComponentTokenService, componentAppId string, httpClient *http.Client) *AuthorizerTokenServiceContainer {
+	return &AuthorizerTokenServiceContainer{
+		componentTokenService: componentTokenService,
+		componentAppId:        componentAppId,
+		httpClient:            httpClient,
+		services:              make(map[string]AuthorizerTokenService),
+	}
+}
+
+// AddAuthorizer 在授权流程完成(或者从数据库恢复)之后调用, 为 authorizerAppId
+// 注册一个新的 AuthorizerTokenService, authorizerRefreshToken 是授权时拿到的
+// 长期 refresh token.
+func (c *AuthorizerTokenServiceContainer) AddAuthorizer(authorizerAppId, authorizerRefreshToken string) (srv AuthorizerTokenService) {
+	srv = NewDefaultAuthorizerTokenService(c.componentTokenService, c.componentAppId, authorizerAppId, authorizerRefreshToken, c.httpClient)
+
+	c.rwmutex.Lock()
+	c.services[authorizerAppId] = srv
+	c.rwmutex.Unlock()
+	return
+}
+
+// Get 返回 authorizerAppId 对应的 AuthorizerTokenService, 如果还没有调用过
+// AddAuthorizer 注册过这个 authorizerAppId, 返回错误.
+func (c *AuthorizerTokenServiceContainer) Get(authorizerAppId string) (srv AuthorizerTokenService, err error) {
+	c.rwmutex.RLock()
+	srv, ok := c.services[authorizerAppId]
+	c.rwmutex.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("authorizer_appid %q 还没有注册, 请先调用 AddAuthorizer", authorizerAppId)
+	}
+	return srv, nil
+}
+
+// Remove 在公众号/小程序取消授权(unauthorized)时调用, 把对应的
+// AuthorizerTokenService 从容器里移除, 并停止它后台的自动刷新 goroutine,
+// 否则这个 goroutine 会拿着一个已经失效的 refresh token 永远轮询下去.
+func (c *AuthorizerTokenServiceContainer) Remove(authorizerAppId string) {
+	c.rwmutex.Lock()
+	srv, ok := c.services[authorizerAppId]
+	delete(c.services, authorizerAppId)
+	c.rwmutex.Unlock()
+
+	if ok {
+		srv.Stop()
+	}
+}
@@ -0,0 +1,245 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// authorizer_access_token 伺服接口, 用法与 mp/client.TokenService 一致.
+// 每一个被授权的公众号/小程序(authorizer_appid)对应一个 AuthorizerTokenService.
+type AuthorizerTokenService interface {
+	Token() (token string, err error)
+	TokenRefresh() (token string, err error)
+
+	// Stop 停止后台的自动刷新 goroutine. 公众号取消授权之后必须调用, 否则
+	// 这个 goroutine 会拿着一个已经失效的 authorizer_refresh_token 永远轮询
+	// 下去. AuthorizerTokenServiceContainer.Remove 会自动调用它.
+	Stop()
+}
+
+var _ AuthorizerTokenService = new(DefaultAuthorizerTokenService)
+
+// DefaultAuthorizerTokenService 是 AuthorizerTokenService 的默认实现.
+// 和公众号自己管理 access_token 不同, 这里换取 authorizer_access_token 用的是
+// 长期有效的 authorizer_refresh_token 而不是 appsecret, 并且每次刷新微信都会
+// 返回一个新的 authorizer_refresh_token, 需要覆盖保存.
+type DefaultAuthorizerTokenService struct {
+	componentTokenService ComponentTokenService
+	componentAppId        string
+	authorizerAppId       string
+
+	refreshTokenMutex sync.RWMutex
+	refreshToken      string
+
+	currentToken struct {
+		rwmutex sync.RWMutex
+		token   string
+		err     error
+	}
+	resetTokenRefreshTickChan chan time.Duration
+
+	stopOnce sync.Once
+	stopChan chan struct{}
+
+	httpClient *http.Client
+}
+
+// NewDefaultAuthorizerTokenService 创建一个新的 DefaultAuthorizerTokenService.
+// authorizerRefreshToken 是授权时微信回调返回的长期 refresh token.
+func NewDefaultAuthorizerTokenService(componentTokenService ComponentTokenService, componentAppId, authorizerAppId, authorizerRefreshToken string, httpClient *http.Client) (srv *DefaultAuthorizerTokenService) {
+	srv = &DefaultAuthorizerTokenService{
+		componentTokenService:     componentTokenService,
+		componentAppId:            componentAppId,
+		authorizerAppId:           authorizerAppId,
+		refreshToken:              authorizerRefreshToken,
+		resetTokenRefreshTickChan: make(chan time.Duration),
+		stopChan:                  make(chan struct{}),
+	}
+
+	if httpClient == nil {
+		srv.httpClient = http.DefaultClient
+	} else {
+		srv.httpClient = httpClient
+	}
+
+	tk, err := srv.getNewToken()
+	if err != nil {
+		srv.currentToken.token = ""
+		srv.currentToken.err = err
+		go srv.tokenAutoUpdate(time.Minute)
+	} else {
+		srv.currentToken.token = tk.Token
+		srv.currentToken.err = nil
+		go srv.tokenAutoUpdate(time.Duration(tk.ExpiresIn) * time.Second)
+	}
+	return
+}
+
+// Stop 停止 tokenAutoUpdate 后台 goroutine, 可以安全地多次调用.
+func (srv *DefaultAuthorizerTokenService) Stop() {
+	srv.stopOnce.Do(func() {
+		close(srv.stopChan)
+	})
+}
+
+func (srv *DefaultAuthorizerTokenService) Token() (token string, err error) {
+	srv.currentToken.rwmutex.RLock()
+	token = srv.currentToken.token
+	err = srv.currentToken.err
+	srv.currentToken.rwmutex.RUnlock()
+	return
+}
+
+func (srv *DefaultAuthorizerTokenService) TokenRefresh() (token string, err error) {
+	srv.currentToken.rwmutex.Lock()
+	defer srv.currentToken.rwmutex.Unlock()
+
+	resp, err := srv.getNewToken()
+	if err != nil {
+		srv.currentToken.token = ""
+		srv.currentToken.err = err
+		select {
+		case srv.resetTokenRefreshTickChan <- time.Minute:
+		case <-srv.stopChan:
+		}
+		return
+	}
+
+	token = resp.Token
+
+	srv.currentToken.token = resp.Token
+	srv.currentToken.err = nil
+	select {
+	case srv.resetTokenRefreshTickChan <- time.Duration(resp.ExpiresIn) * time.Second:
+	case <-srv.stopChan:
+	}
+	return
+}
+
+type authorizerTokenResponse struct {
+	Token        string `json:"authorizer_access_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"authorizer_refresh_token"`
+}
+
+// 从微信服务器获取新的 authorizer_access_token, 同时会拿到一个新的
+// authorizer_refresh_token, 必须覆盖保存, 否则下一次刷新会失败.
+func (srv *DefaultAuthorizerTokenService) getNewToken() (resp *authorizerTokenResponse, err error) {
+	componentToken, err := srv.componentTokenService.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	srv.refreshTokenMutex.RLock()
+	refreshToken := srv.refreshToken
+	srv.refreshTokenMutex.RUnlock()
+
+	body, err := json.Marshal(struct {
+		ComponentAppId         string `json:"component_appid"`
+		AuthorizerAppId        string `json:"authorizer_appid"`
+		AuthorizerRefreshToken string `json:"authorizer_refresh_token"`
+	}{srv.componentAppId, srv.authorizerAppId, refreshToken})
+	if err != nil {
+		return nil, err
+	}
+
+	_url := "https://api.weixin.qq.com/cgi-bin/component/api_authorizer_token?component_access_token=" + componentToken
+
+	httpResp, err := srv.httpClient.Post(_url, "application/json; charset=utf-8", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("http.Status: %s", httpResp.Status)
+	}
+
+	var result struct {
+		authorizerTokenResponse
+		Error
+	}
+	if err = json.NewDecoder(httpResp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.ErrCode != 0 {
+		return nil, &result.Error
+	}
+
+	switch {
+	case result.ExpiresIn > 60*60:
+		result.ExpiresIn -= 60 * 10
+	case result.ExpiresIn > 60*30:
+		result.ExpiresIn -= 60 * 5
+	case result.ExpiresIn > 60*5:
+		result.ExpiresIn -= 60
+	case result.ExpiresIn > 60:
+		result.ExpiresIn -= 10
+	case result.ExpiresIn > 0:
+		// 没有办法了, 死马当做活马医了
+	default:
+		return nil, fmt.Errorf("expires_in 应该是正整数, 现在为: %d", result.ExpiresIn)
+	}
+
+	if result.RefreshToken != "" {
+		srv.refreshTokenMutex.Lock()
+		srv.refreshToken = result.RefreshToken
+		srv.refreshTokenMutex.Unlock()
+	}
+	return &result.authorizerTokenResponse, nil
+}
+
+// 单独一个 goroutine 来定时获取 authorizer_access_token, 与 mp/client 的
+// (*DefaultTokenService).tokenAutoUpdate 完全相同的结构.
+func (srv *DefaultAuthorizerTokenService) tokenAutoUpdate(tickDuration time.Duration) {
+	const defaultTickDuration = time.Minute
+	var ticker *time.Ticker
+
+NEW_TICK_DURATION:
+	ticker = time.NewTicker(tickDuration)
+	for {
+		select {
+		case <-srv.stopChan:
+			ticker.Stop()
+			return
+
+		case tickDuration = <-srv.resetTokenRefreshTickChan:
+			ticker.Stop()
+			goto NEW_TICK_DURATION
+
+		case <-ticker.C:
+			srv.currentToken.rwmutex.Lock()
+
+			resp, err := srv.getNewToken()
+			if err != nil {
+				srv.currentToken.token = ""
+				srv.currentToken.err = err
+
+				srv.currentToken.rwmutex.Unlock()
+
+				if tickDuration != defaultTickDuration {
+					ticker.Stop()
+					tickDuration = defaultTickDuration
+					goto NEW_TICK_DURATION
+				}
+
+			} else {
+				srv.currentToken.token = resp.Token
+				srv.currentToken.err = nil
+
+				srv.currentToken.rwmutex.Unlock()
+
+				newTickDuration := time.Duration(resp.ExpiresIn) * time.Second
+				if tickDuration != newTickDuration {
+					ticker.Stop()
+					tickDuration = newTickDuration
+					goto NEW_TICK_DURATION
+				}
+			}
+		}
+	}
+}
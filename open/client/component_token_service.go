@@ -0,0 +1,248 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// component_access_token 伺服接口, 用法与 mp/client.TokenService 一致.
+type ComponentTokenService interface {
+	// Token 获取 component_access_token, 该 token 一般缓存在某个地方.
+	Token() (token string, err error)
+
+	// TokenRefresh 从微信服务器获取新的 component_access_token.
+	// 同 mp/client.TokenService.TokenRefresh, 请谨慎调用.
+	TokenRefresh() (token string, err error)
+
+	// PreAuthCode 获取用于发起授权流程的 pre_auth_code.
+	PreAuthCode() (code string, err error)
+}
+
+var _ ComponentTokenService = new(DefaultComponentTokenService)
+
+// DefaultComponentTokenService 是 ComponentTokenService 的默认实现, 结构和
+// mp/client.DefaultTokenService 基本一致, 只是多了一个 component_verify_ticket 的来源.
+type DefaultComponentTokenService struct {
+	componentAppId, componentAppSecret string
+	ticketStore                        VerifyTicketStore
+
+	currentToken struct {
+		rwmutex sync.RWMutex
+		token   string
+		err     error
+	}
+	resetTokenRefreshTickChan chan time.Duration
+
+	httpClient *http.Client
+}
+
+func NewDefaultComponentTokenService(componentAppId, componentAppSecret string, ticketStore VerifyTicketStore, httpClient *http.Client) (srv *DefaultComponentTokenService) {
+	srv = &DefaultComponentTokenService{
+		componentAppId:            componentAppId,
+		componentAppSecret:        componentAppSecret,
+		ticketStore:               ticketStore,
+		resetTokenRefreshTickChan: make(chan time.Duration),
+	}
+
+	if httpClient == nil {
+		srv.httpClient = http.DefaultClient
+	} else {
+		srv.httpClient = httpClient
+	}
+
+	tk, err := srv.getNewToken()
+	if err != nil {
+		srv.currentToken.token = ""
+		srv.currentToken.err = err
+		go srv.tokenAutoUpdate(time.Minute) // 一分钟后尝试
+	} else {
+		srv.currentToken.token = tk.Token
+		srv.currentToken.err = nil
+		go srv.tokenAutoUpdate(time.Duration(tk.ExpiresIn) * time.Second)
+	}
+	return
+}
+
+func (srv *DefaultComponentTokenService) Token() (token string, err error) {
+	srv.currentToken.rwmutex.RLock()
+	token = srv.currentToken.token
+	err = srv.currentToken.err
+	srv.currentToken.rwmutex.RUnlock()
+	return
+}
+
+func (srv *DefaultComponentTokenService) TokenRefresh() (token string, err error) {
+	srv.currentToken.rwmutex.Lock()
+	defer srv.currentToken.rwmutex.Unlock()
+
+	resp, err := srv.getNewToken()
+	if err != nil {
+		srv.currentToken.token = ""
+		srv.currentToken.err = err
+		srv.resetTokenRefreshTickChan <- time.Minute
+		return
+	}
+
+	token = resp.Token
+
+	srv.currentToken.token = resp.Token
+	srv.currentToken.err = nil
+	srv.resetTokenRefreshTickChan <- time.Duration(resp.ExpiresIn) * time.Second
+	return
+}
+
+// PreAuthCode 获取 pre_auth_code, 用来构造跳转到微信授权页面的链接,
+// 参见 https://open.weixin.qq.com/cgi-bin/showdocument?action=dir_list&t=resource/res_list&verify=1&id=open1419318587
+func (srv *DefaultComponentTokenService) PreAuthCode() (code string, err error) {
+	token, err := srv.Token()
+	if err != nil {
+		return "", err
+	}
+
+	_url := "https://api.weixin.qq.com/cgi-bin/component/api_create_preauthcode?component_access_token=" + token
+
+	body, err := json.Marshal(struct {
+		ComponentAppId string `json:"component_appid"`
+	}{srv.componentAppId})
+	if err != nil {
+		return "", err
+	}
+
+	httpResp, err := srv.httpClient.Post(_url, "application/json; charset=utf-8", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("http.Status: %s", httpResp.Status)
+	}
+
+	var result struct {
+		PreAuthCode string `json:"pre_auth_code"`
+		ExpiresIn   int64  `json:"expires_in"`
+		Error
+	}
+	if err = json.NewDecoder(httpResp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.ErrCode != 0 {
+		return "", &result.Error
+	}
+	return result.PreAuthCode, nil
+}
+
+// componentTokenResponse 是 /cgi-bin/component/api_component_token 成功时返回的消息格式
+type componentTokenResponse struct {
+	Token     string `json:"component_access_token"`
+	ExpiresIn int64  `json:"expires_in"`
+}
+
+// 从微信服务器获取新的 component_access_token, 逻辑和 mp/client 的 getNewToken 一致,
+// 使用相同的缓冲区策略, 只是请求参数和 URL 不同.
+func (srv *DefaultComponentTokenService) getNewToken() (resp *componentTokenResponse, err error) {
+	ticket := srv.ticketStore.Get()
+	if ticket == "" {
+		return nil, fmt.Errorf("component_verify_ticket 还没有收到, 无法获取 component_access_token")
+	}
+
+	body, err := json.Marshal(struct {
+		ComponentAppId        string `json:"component_appid"`
+		ComponentAppSecret    string `json:"component_appsecret"`
+		ComponentVerifyTicket string `json:"component_verify_ticket"`
+	}{srv.componentAppId, srv.componentAppSecret, ticket})
+	if err != nil {
+		return nil, err
+	}
+
+	const _url = "https://api.weixin.qq.com/cgi-bin/component/api_component_token"
+
+	httpResp, err := srv.httpClient.Post(_url, "application/json; charset=utf-8", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("http.Status: %s", httpResp.Status)
+	}
+
+	var result struct {
+		componentTokenResponse
+		Error
+	}
+	if err = json.NewDecoder(httpResp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.ErrCode != 0 {
+		return nil, &result.Error
+	}
+
+	// 与 mp/client 的 getNewToken 相同的缓冲区策略, 留出一点时间余量应对网络延时.
+	switch {
+	case result.ExpiresIn > 60*60:
+		result.ExpiresIn -= 60 * 10
+	case result.ExpiresIn > 60*30:
+		result.ExpiresIn -= 60 * 5
+	case result.ExpiresIn > 60*5:
+		result.ExpiresIn -= 60
+	case result.ExpiresIn > 60:
+		result.ExpiresIn -= 10
+	case result.ExpiresIn > 0:
+		// 没有办法了, 死马当做活马医了
+	default:
+		return nil, fmt.Errorf("expires_in 应该是正整数, 现在为: %d", result.ExpiresIn)
+	}
+	return &result.componentTokenResponse, nil
+}
+
+// 单独一个 goroutine 来定时获取 component_access_token, 与 mp/client 的
+// (*DefaultTokenService).tokenAutoUpdate 完全相同的结构.
+func (srv *DefaultComponentTokenService) tokenAutoUpdate(tickDuration time.Duration) {
+	const defaultTickDuration = time.Minute
+	var ticker *time.Ticker
+
+NEW_TICK_DURATION:
+	ticker = time.NewTicker(tickDuration)
+	for {
+		select {
+		case tickDuration = <-srv.resetTokenRefreshTickChan:
+			ticker.Stop()
+			goto NEW_TICK_DURATION
+
+		case <-ticker.C:
+			srv.currentToken.rwmutex.Lock()
+
+			resp, err := srv.getNewToken()
+			if err != nil {
+				srv.currentToken.token = ""
+				srv.currentToken.err = err
+
+				srv.currentToken.rwmutex.Unlock()
+
+				if tickDuration != defaultTickDuration {
+					ticker.Stop()
+					tickDuration = defaultTickDuration
+					goto NEW_TICK_DURATION
+				}
+
+			} else {
+				srv.currentToken.token = resp.Token
+				srv.currentToken.err = nil
+
+				srv.currentToken.rwmutex.Unlock()
+
+				newTickDuration := time.Duration(resp.ExpiresIn) * time.Second
+				if tickDuration != newTickDuration {
+					ticker.Stop()
+					tickDuration = newTickDuration
+					goto NEW_TICK_DURATION
+				}
+			}
+		}
+	}
+}
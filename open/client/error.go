@@ -0,0 +1,13 @@
+package client
+
+import "fmt"
+
+// Error 是微信服务器返回的错误信息, 与 mp/client.Error 保持相同的结构和语义.
+type Error struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("errcode: %d, errmsg: %s", e.ErrCode, e.ErrMsg)
+}
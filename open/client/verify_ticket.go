@@ -0,0 +1,79 @@
+// @description wechat 是腾讯微信公众平台 api 的 golang 语言封装
+// @link        https://github.com/chanxuehong/wechat for the canonical source repository
+// @license     https://github.com/chanxuehong/wechat/blob/master/LICENSE
+// @authors     chanxuehong(chanxuehong@gmail.com)
+
+// Package client 是开放平台(第三方平台)的 component_access_token / authorizer_access_token
+// 管理, 对应 mp/client 里公众号自己的 access token 管理.
+package client
+
+import (
+	"encoding/xml"
+	"net/http"
+	"sync"
+)
+
+// VerifyTicketStore 用来保存微信后台每隔 10 分钟推送过来的 component_verify_ticket.
+// component_verify_ticket 是获取 component_access_token 的必要参数之一, 推送是被动的,
+// 所以需要一个地方先把它存起来.
+type VerifyTicketStore interface {
+	// Get 返回当前保存的 component_verify_ticket, 如果还没有收到过推送, 返回 "".
+	Get() (ticket string)
+
+	// Set 保存最新收到的 component_verify_ticket.
+	Set(ticket string)
+}
+
+var _ VerifyTicketStore = new(MemoryVerifyTicketStore)
+
+// MemoryVerifyTicketStore 是 VerifyTicketStore 的进程内存实现, 仅适用于单进程场景;
+// 多进程部署请自己实现一个基于 Redis 等外部存储的 VerifyTicketStore.
+type MemoryVerifyTicketStore struct {
+	rwmutex sync.RWMutex
+	ticket  string
+}
+
+func NewMemoryVerifyTicketStore() *MemoryVerifyTicketStore {
+	return &MemoryVerifyTicketStore{}
+}
+
+func (store *MemoryVerifyTicketStore) Get() (ticket string) {
+	store.rwmutex.RLock()
+	ticket = store.ticket
+	store.rwmutex.RUnlock()
+	return
+}
+
+func (store *MemoryVerifyTicketStore) Set(ticket string) {
+	store.rwmutex.Lock()
+	store.ticket = ticket
+	store.rwmutex.Unlock()
+}
+
+// verifyTicketPushMessage 是微信后台推送 component_verify_ticket 时的消息体(已解密后的明文).
+// 具体的消息加解密(AES + 消息签名校验)请参考公众号/企业号的加解密方案, 这里不再重复实现.
+type verifyTicketPushMessage struct {
+	XMLName               xml.Name `xml:"xml"`
+	AppId                 string   `xml:"AppId"`
+	InfoType              string   `xml:"InfoType"`
+	ComponentVerifyTicket string   `xml:"ComponentVerifyTicket"`
+}
+
+// NewVerifyTicketHandler 返回一个 http.Handler, 用来接收微信推送的 component_verify_ticket
+// 回调(已经过消息解密的明文 body), 并把 ticket 写入 store.
+//
+// NOTE: 接入时要先按公众号第三方平台的消息加解密方案把密文解密成明文, 再把请求转发给这个
+// handler, 这里只负责解析明文 xml 和保存 ticket.
+func NewVerifyTicketHandler(store VerifyTicketStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg verifyTicketPushMessage
+		if err := xml.NewDecoder(r.Body).Decode(&msg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if msg.InfoType == "component_verify_ticket" && msg.ComponentVerifyTicket != "" {
+			store.Set(msg.ComponentVerifyTicket)
+		}
+		w.Write([]byte("success"))
+	})
+}
@@ -49,4 +49,24 @@ func snsUserInfoURL(accessToken, openid, lang string) string {
 		openid +
 		"&lang=" +
 		lang
-}
\ No newline at end of file
+}
+
+// SnsOAuth2AuthURL 导出 snsOAuth2AuthURL, 供 wechat/oauth2 包使用.
+func SnsOAuth2AuthURL(appid, redirectURL, scope, state string) string {
+	return snsOAuth2AuthURL(appid, redirectURL, scope, state)
+}
+
+// SnsOAuth2TokenURL 导出 snsOAuth2TokenURL, 供 wechat/oauth2 包使用.
+func SnsOAuth2TokenURL(appid, appsecret, code string) string {
+	return snsOAuth2TokenURL(appid, appsecret, code)
+}
+
+// SnsOAuth2RefreshTokenURL 导出 snsOAuth2RefreshTokenURL, 供 wechat/oauth2 包使用.
+func SnsOAuth2RefreshTokenURL(appid, refreshToken string) string {
+	return snsOAuth2RefreshTokenURL(appid, refreshToken)
+}
+
+// SnsUserInfoURL 导出 snsUserInfoURL, 供 wechat/oauth2 包使用.
+func SnsUserInfoURL(accessToken, openid, lang string) string {
+	return snsUserInfoURL(accessToken, openid, lang)
+}